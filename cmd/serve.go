@@ -0,0 +1,168 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-gremlins/gremlins/internal/gomodule"
+	"github.com/go-gremlins/gremlins/pkg/log"
+	"github.com/go-gremlins/gremlins/pkg/server"
+)
+
+type serveCmd struct {
+	cmd *cobra.Command
+}
+
+const (
+	serveCommandName = "serve"
+
+	paramSocket = "socket"
+)
+
+func newServeCmd(ctx context.Context) (*serveCmd, error) {
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s [path]", serveCommandName),
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Run gremlins as a long-running server for editor integration.",
+		Long: `'gremlins serve' keeps a warm workdir, module graph and coverage profile in
+memory for a Go module, and exposes a JSON-RPC 2.0 API over stdio (and,
+if --socket is set, a Unix socket) so editors can request mutation analysis
+incrementally instead of paying the full-module cost on every save.`,
+		RunE: runServe(ctx),
+	}
+
+	cmd.Flags().String(paramSocket, "", "additionally listen for JSON-RPC connections on this Unix socket")
+
+	return &serveCmd{cmd: cmd}, nil
+}
+
+func runServe(ctx context.Context) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		log.Infoln("Starting server...")
+		currPath, runDir, err := changePath(args, os.Chdir, os.Getwd)
+		if err != nil {
+			return err
+		}
+
+		workDir, err := os.MkdirTemp(os.TempDir(), "gremlins-")
+		if err != nil {
+			return fmt.Errorf("impossible to create the workdir: %w", err)
+		}
+		defer cleanUp(workDir, runDir)
+
+		mod, err := gomodule.Init(currPath)
+		if err != nil {
+			return fmt.Errorf("%q is not in a Go module: %w", currPath, err)
+		}
+
+		srv := server.New(newEngine(workDir, mod))
+		socketPath, err := cmd.Flags().GetString(paramSocket)
+		if err != nil {
+			return err
+		}
+
+		return serveUntilDone(ctx, srv, socketPath)
+	}
+}
+
+// serveUntilDone serves JSON-RPC requests over stdio and, if socketPath is
+// set, over a Unix socket accepting one connection at a time per client,
+// until ctx is cancelled, stdin reaches EOF, or either transport fails. If
+// the socket cannot be listened on, it returns that error before serving
+// anything. Otherwise it only returns once
+// every in-flight Serve call - stdio and any accepted socket connections -
+// has actually stopped, so the caller's deferred workdir cleanup never
+// races a connection still using it.
+func serveUntilDone(ctx context.Context, srv *server.Server, socketPath string) error {
+	errCh := make(chan error, 2)
+	wg := &sync.WaitGroup{}
+
+	var ln net.Listener
+	if socketPath != "" {
+		var err error
+		ln, err = net.Listen("unix", socketPath)
+		if err != nil {
+			return fmt.Errorf("impossible to listen on %q: %w", socketPath, err)
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errCh <- srv.Serve(ctx, os.Stdin, os.Stdout)
+	}()
+
+	if ln != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveSocket(ctx, srv, ln, wg, errCh)
+		}()
+	}
+
+	var result error
+	select {
+	case <-ctx.Done():
+	case result = <-errCh:
+	}
+
+	// Unblock every Serve call still blocked decoding a request - closing
+	// the reader it is blocked on is the only portable way to do that -
+	// then wait for all of them, including every accepted connection's own
+	// goroutine, to actually return before the caller proceeds to clean up
+	// the scratch workdir they may still be using.
+	_ = os.Stdin.Close()
+	if ln != nil {
+		_ = ln.Close()
+	}
+	wg.Wait()
+
+	return result
+}
+
+func serveSocket(ctx context.Context, srv *server.Server, ln net.Listener, wg *sync.WaitGroup, errCh chan<- error) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			errCh <- err
+
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				_ = conn.Close()
+			}()
+			go func() {
+				<-ctx.Done()
+				_ = conn.Close()
+			}()
+			if err := srv.Serve(ctx, conn, conn); err != nil {
+				log.Errorf("serve: connection error: %s\n", err)
+			}
+		}()
+	}
+}
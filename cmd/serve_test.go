@@ -0,0 +1,106 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-gremlins/gremlins/pkg/coverage"
+	"github.com/go-gremlins/gremlins/pkg/report"
+	"github.com/go-gremlins/gremlins/pkg/server"
+)
+
+// noopEngine is a stand-in for *server.Engine: it implements the same
+// methods without needing a real gomodule.GoModule, workdir.Dealer or
+// coverage.Coverage, which this test has no use for.
+type noopEngine struct{}
+
+func (noopEngine) RefreshCoverage() (coverage.Result, error) { return coverage.Result{}, nil }
+func (noopEngine) Run(_ context.Context) (report.Results, error) {
+	return report.Results{}, nil
+}
+func (noopEngine) RunFile(_ context.Context, _ string) (report.Results, error) {
+	return report.Results{}, nil
+}
+func (noopEngine) RunMutant(_ context.Context, _ string) (report.Results, error) {
+	return report.Results{}, nil
+}
+
+// TestServeSocketWaitsForConnectionBeforeReturning exercises the shutdown
+// sequencing fixed in serveSocket: a still-open connection must keep
+// wg.Wait() blocked until ctx is cancelled, at which point the connection
+// is closed and the per-connection goroutine actually exits - so a caller
+// waiting on wg never proceeds to clean up while a connection might still
+// be using the scratch workdir.
+func TestServeSocketWaitsForConnectionBeforeReturning(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "gremlins.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("impossible to listen on %q: %v", socketPath, err)
+	}
+
+	srv := server.New(noopEngine{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wg := &sync.WaitGroup{}
+	errCh := make(chan error, 1)
+	go serveSocket(ctx, srv, ln, wg, errCh)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("impossible to dial %q: %v", socketPath, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// A request/response round trip confirms the server is actively
+	// serving this connection before we start asserting on wg.
+	if err := json.NewEncoder(conn).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "ping"}); err != nil {
+		t.Fatalf("impossible to send request: %v", err)
+	}
+	var resp json.RawMessage
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatalf("impossible to read response: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("wg.Wait() returned while a connection was still open")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	cancel()
+	_ = ln.Close()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("wg.Wait() did not return after ctx was cancelled")
+	}
+}
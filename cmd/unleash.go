@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -33,9 +34,9 @@ import (
 	"github.com/go-gremlins/gremlins/pkg/coverage"
 	"github.com/go-gremlins/gremlins/pkg/log"
 	"github.com/go-gremlins/gremlins/pkg/mutant"
-	"github.com/go-gremlins/gremlins/pkg/mutator"
 	"github.com/go-gremlins/gremlins/pkg/mutator/workdir"
 	"github.com/go-gremlins/gremlins/pkg/report"
+	"github.com/go-gremlins/gremlins/pkg/server"
 )
 
 type unleashCmd struct {
@@ -45,9 +46,14 @@ type unleashCmd struct {
 const (
 	commandName = "unleash"
 
-	paramBuildTags = "tags"
-	paramDryRun    = "dry-run"
-	paramOutput    = "output"
+	paramBuildTags       = "tags"
+	paramDryRun          = "dry-run"
+	paramOutput          = "output"
+	paramCoverageMode    = "coverage-mode"
+	paramCoverageProfile = "coverage-profile"
+	paramCoverageWorkers = "coverage-workers"
+	paramTestTimeout     = "test-timeout"
+	paramNetrc           = "netrc"
 
 	// Thresholds.
 	paramThresholdEfficacy  = "threshold-efficacy"
@@ -145,19 +151,36 @@ func run(ctx context.Context, workDir, currPath string) (report.Results, error)
 	if err != nil {
 		return report.Results{}, fmt.Errorf("%q is not in a Go module: %w", currPath, err)
 	}
-	c := coverage.New(workDir, mod)
 
-	p, err := c.Run()
-	if err != nil {
-		return report.Results{}, fmt.Errorf("failed to gather coverage: %w", err)
-	}
+	eng := newEngine(workDir, mod)
 
+	return eng.Run(ctx)
+}
+
+// newEngine builds the server.Engine for a single `gremlins unleash` run.
+// `gremlins serve` builds the same Engine once and reuses it across many
+// requests instead.
+func newEngine(workDir string, mod gomodule.GoModule) *server.Engine {
 	d := workdir.NewDealer(workDir, mod.Root)
 
-	mut := mutator.New(mod, p, d)
-	results := mut.Run(ctx)
+	return server.NewEngine(mod, d, newCoverage(workDir, mod))
+}
+
+// newCoverage builds the coverage.Coverage to use for this run: it reuses
+// an externally supplied profile when --coverage-profile is set, to avoid
+// duplicating a coverage run that CI already performed.
+func newCoverage(workDir string, mod gomodule.GoModule) *coverage.Coverage {
+	profilePath := configuration.Get[string](configuration.UnleashCoverageProfileKey)
+	if profilePath == "" {
+		return coverage.New(workDir, mod)
+	}
+
+	var opts []coverage.Option
+	if timeout := configuration.Get[time.Duration](configuration.UnleashTestTimeoutKey); timeout > 0 {
+		opts = append(opts, coverage.WithTestTimeout(timeout))
+	}
 
-	return results, nil
+	return coverage.NewFromProfile(workDir, profilePath, mod, opts...)
 }
 
 func changePath(args []string, chdir func(dir string) error, getwd func() (string, error)) (string, string, error) {
@@ -195,6 +218,11 @@ func setFlagsOnCmd(cmd *cobra.Command) error {
 		{Name: paramDryRun, CfgKey: configuration.UnleashDryRunKey, Shorthand: "d", DefaultV: false, Usage: "find mutations but do not executes tests"},
 		{Name: paramBuildTags, CfgKey: configuration.UnleashTagsKey, Shorthand: "t", DefaultV: "", Usage: "a comma-separated list of build tags"},
 		{Name: paramOutput, CfgKey: configuration.UnleashOutputKey, Shorthand: "o", DefaultV: "", Usage: "set the output file for machine readable results"},
+		{Name: paramCoverageMode, CfgKey: configuration.UnleashCoverageModeKey, DefaultV: "legacy", Usage: "coverage collection mode, one of \"legacy\", \"binary\""},
+		{Name: paramCoverageProfile, CfgKey: configuration.UnleashCoverageProfileKey, DefaultV: "", Usage: "reuse an existing coverage profile instead of running the coverage step"},
+		{Name: paramCoverageWorkers, CfgKey: configuration.UnleashCoverageWorkersKey, DefaultV: 0, Usage: "number of packages to test concurrently in binary coverage mode (default GOMAXPROCS)"},
+		{Name: paramNetrc, CfgKey: configuration.UnleashNetrcKey, DefaultV: "", Usage: "netrc file to use to authenticate private module hosts (default $NETRC or ~/.netrc)"},
+		{Name: paramTestTimeout, CfgKey: configuration.UnleashTestTimeoutKey, DefaultV: time.Duration(0), Usage: "test timeout to use with --coverage-profile, skips the estimation run"},
 		{Name: paramThresholdEfficacy, CfgKey: configuration.UnleashThresholdEfficacyKey, DefaultV: float64(0), Usage: "threshold for code-efficacy percent"},
 		{Name: paramThresholdMCoverage, CfgKey: configuration.UnleashThresholdMCoverageKey, DefaultV: float64(0), Usage: "threshold for mutant-coverage percent"},
 	}
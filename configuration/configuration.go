@@ -0,0 +1,102 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package configuration centralises the configuration keys read by the
+// gremlins commands and their flags, backed by viper so values can come
+// from flags, environment variables or a config file interchangeably.
+package configuration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/go-gremlins/gremlins/pkg/mutant"
+)
+
+// Configuration keys for the 'unleash' command.
+const (
+	UnleashDryRunKey             = "unleash.dry-run"
+	UnleashTagsKey               = "unleash.tags"
+	UnleashOutputKey             = "unleash.output"
+	UnleashThresholdEfficacyKey  = "unleash.threshold-efficacy"
+	UnleashThresholdMCoverageKey = "unleash.threshold-mcover"
+
+	// UnleashCoverageModeKey selects the coverage collection mode, one of
+	// "legacy" (the default, `-coverprofile`) or "binary" (the Go 1.20+
+	// GOCOVERDIR format). See coverage.WithBinaryCoverage.
+	UnleashCoverageModeKey = "unleash.coverage-mode"
+
+	// UnleashCoverageProfileKey points at an externally supplied coverage
+	// profile, bypassing the coverage run entirely. See
+	// coverage.NewFromProfile.
+	UnleashCoverageProfileKey = "unleash.coverage-profile"
+
+	// UnleashTestTimeoutKey, used together with UnleashCoverageProfileKey,
+	// sets the test-binary timeout explicitly, skipping the estimation run
+	// that would otherwise execute the test suite once more.
+	UnleashTestTimeoutKey = "unleash.test-timeout"
+
+	// UnleashCoverageWorkersKey bounds the worker pool used to run test
+	// binaries concurrently in binary coverage mode. Defaults to
+	// runtime.GOMAXPROCS(0) when unset or not positive.
+	UnleashCoverageWorkersKey = "unleash.coverage-workers"
+
+	// UnleashNetrcKey overrides $NETRC/~/.netrc as the source of
+	// credentials for private module hosts. See internal/auth.Env.
+	UnleashNetrcKey = "unleash.netrc"
+)
+
+// MutantTypeEnabledKey returns the configuration key that enables or
+// disables the given mutant type.
+func MutantTypeEnabledKey(t mutant.Type) string {
+	return fmt.Sprintf("mutant-types.%s.enabled", strings.ToLower(t.String()))
+}
+
+// IsDefaultEnabled reports whether a mutant type is enabled out of the box.
+func IsDefaultEnabled(t mutant.Type) bool {
+	enabled, found := defaultEnabledMutantTypes[t]
+	if !found {
+		return true
+	}
+
+	return enabled
+}
+
+// defaultEnabledMutantTypes lists the mutant types that are NOT enabled by
+// default; every other type defaults to enabled.
+var defaultEnabledMutantTypes = map[mutant.Type]bool{}
+
+// Get retrieves a configuration value by key, type-asserting it to T. It
+// returns the zero value of T if the key is unset or holds a different
+// type.
+func Get[T any](name string) T {
+	v, ok := viper.Get(name).(T)
+	if !ok {
+		var zero T
+
+		return zero
+	}
+
+	return v
+}
+
+// Set stores a configuration value by key, so flags can populate viper
+// with their parsed value and default.
+func Set(name string, value any) {
+	viper.Set(name, value)
+}
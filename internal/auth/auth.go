@@ -0,0 +1,156 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Env reads the netrc file at path (or $NETRC, or ~/.netrc if path is
+// empty), keeps only the machine entries matching a host pattern listed in
+// GOPRIVATE/GONOSUMCHECK, plus the "default" entry if there is one (see
+// filterMachines), and materializes them into a private netrc file
+// under dir. It returns the extra environment variables to append to a
+// child `go` process so it authenticates against those hosts, or nil if
+// there is nothing to add.
+func Env(path, dir string) ([]string, error) {
+	netrcPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if netrcPath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(netrcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("impossible to read netrc file %q: %w", netrcPath, err)
+	}
+
+	patterns := privatePatterns()
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	machines := parseNetrc(string(data))
+	filtered := filterMachines(machines, patterns)
+	if len(filtered) == 0 {
+		return nil, nil
+	}
+
+	tmpPath, err := writeNetrc(dir, filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"NETRC=" + tmpPath}, nil
+}
+
+// resolvePath returns the netrc file to use: the explicit path, $NETRC, or
+// the default ~/.netrc, in that order of precedence.
+func resolvePath(explicit string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	if p := os.Getenv("NETRC"); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("impossible to determine the home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// privatePatterns returns the glob patterns listed in GOPRIVATE and
+// GONOSUMCHECK, the two environment variables the Go toolchain already uses
+// to mark hosts as private.
+func privatePatterns() []string {
+	var patterns []string
+	for _, envVar := range []string{"GOPRIVATE", "GONOSUMCHECK"} {
+		v := os.Getenv(envVar)
+		if v == "" {
+			continue
+		}
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				patterns = append(patterns, p)
+			}
+		}
+	}
+
+	return patterns
+}
+
+// filterMachines keeps the machine entries whose name matches one of
+// patterns, a list of GOPRIVATE-style globs (e.g. "*.corp.example.com"),
+// plus the netrc "default" entry, if any, found via the same lookup used
+// to resolve a single host's fallback credentials - so a netrc holding
+// only a default entry still authenticates private hosts instead of
+// being silently dropped.
+func filterMachines(machines []machine, patterns []string) []machine {
+	var filtered []machine
+	for _, m := range machines {
+		if m.name == "" {
+			continue
+		}
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, m.name); ok {
+				filtered = append(filtered, m)
+				break
+			}
+		}
+	}
+	if def, ok := lookup(machines, ""); ok {
+		filtered = append(filtered, def)
+	}
+
+	return filtered
+}
+
+// writeNetrc materializes machines into a netrc file under dir, so it can
+// be pointed at via the NETRC environment variable without touching the
+// user's own ~/.netrc. A machine with an empty name is written back out as
+// a "default" entry, preserving its fallback semantics for the go tool's
+// own netrc reader.
+func writeNetrc(dir string, machines []machine) (string, error) {
+	var b strings.Builder
+	for _, m := range machines {
+		if m.name == "" {
+			fmt.Fprintf(&b, "default login %s password %s\n", m.login, m.password)
+
+			continue
+		}
+		fmt.Fprintf(&b, "machine %s login %s password %s\n", m.name, m.login, m.password)
+	}
+
+	tmpPath := filepath.Join(dir, "netrc")
+	if err := os.WriteFile(tmpPath, []byte(b.String()), 0o600); err != nil {
+		return "", fmt.Errorf("impossible to write netrc file: %w", err)
+	}
+
+	return tmpPath, nil
+}
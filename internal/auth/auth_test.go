@@ -0,0 +1,107 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withNetrc(t *testing.T, content string) string {
+	t.Helper()
+	netrcPath := filepath.Join(t.TempDir(), ".netrc")
+	if err := os.WriteFile(netrcPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("impossible to write temp netrc: %v", err)
+	}
+
+	return netrcPath
+}
+
+func TestEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		netrc      string
+		goprivate  string
+		wantNETRC  bool
+		wantLogins []string
+	}{
+		{
+			name:      "no GOPRIVATE configured is a no-op",
+			netrc:     "machine corp.example.com login jdoe password secret\n",
+			goprivate: "",
+			wantNETRC: false,
+		},
+		{
+			name:       "matching machine entry is written out",
+			netrc:      "machine corp.example.com login jdoe password secret\n",
+			goprivate:  "corp.example.com",
+			wantNETRC:  true,
+			wantLogins: []string{"jdoe"},
+		},
+		{
+			name:       "default-only netrc still authenticates private hosts",
+			netrc:      "default login anon password anon\n",
+			goprivate:  "corp.example.com",
+			wantNETRC:  true,
+			wantLogins: []string{"anon"},
+		},
+		{
+			name:      "no match and no default is a no-op",
+			netrc:     "machine other.example.com login jdoe password secret\n",
+			goprivate: "corp.example.com",
+			wantNETRC: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GOPRIVATE", test.goprivate)
+			t.Setenv("GONOSUMCHECK", "")
+			netrcPath := withNetrc(t, test.netrc)
+			dir := t.TempDir()
+
+			env, err := Env(netrcPath, dir)
+			if err != nil {
+				t.Fatalf("Env() returned an error: %v", err)
+			}
+
+			if !test.wantNETRC {
+				if len(env) != 0 {
+					t.Fatalf("expected no extra env, got: %v", env)
+				}
+
+				return
+			}
+
+			if len(env) != 1 || !strings.HasPrefix(env[0], "NETRC=") {
+				t.Fatalf("expected a single NETRC= entry, got: %v", env)
+			}
+
+			written, err := os.ReadFile(strings.TrimPrefix(env[0], "NETRC="))
+			if err != nil {
+				t.Fatalf("impossible to read generated netrc: %v", err)
+			}
+			for _, login := range test.wantLogins {
+				if !strings.Contains(string(written), "login "+login) {
+					t.Errorf("expected generated netrc to contain login %q, got:\n%s", login, written)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package auth reads netrc-style credentials and makes them available to
+// the child `go` processes spawned while gathering coverage, so private
+// modules behind HTTPS auth can be fetched. The netrc parser follows the
+// same shape as the Go toolchain's own internal netrc reader.
+package auth
+
+import (
+	"bufio"
+	"strings"
+)
+
+// machine is a single "machine" entry of a netrc file.
+type machine struct {
+	name     string
+	login    string
+	password string
+	account  string
+}
+
+// parseNetrc parses the content of a netrc file, returning one machine per
+// "machine" or "default" entry, in file order. It recognises the standard
+// machine/login/password/account/macdef tokens, skips comments (introduced
+// by '#') and macro definitions (macdef ... up to the next blank line), and
+// treats "default" as a machine named "".
+func parseNetrc(data string) []machine {
+	var machines []machine
+	var cur *machine
+	var inMacdef bool
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacdef {
+			if strings.TrimSpace(line) == "" {
+				inMacdef = false
+			}
+			continue
+		}
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "machine":
+				machines = append(machines, machine{})
+				cur = &machines[len(machines)-1]
+				if i+1 < len(fields) {
+					cur.name = fields[i+1]
+					i++
+				}
+			case "default":
+				machines = append(machines, machine{})
+				cur = &machines[len(machines)-1]
+			case "login":
+				if cur != nil && i+1 < len(fields) {
+					cur.login = fields[i+1]
+					i++
+				}
+			case "password":
+				if cur != nil && i+1 < len(fields) {
+					cur.password = fields[i+1]
+					i++
+				}
+			case "account":
+				if cur != nil && i+1 < len(fields) {
+					cur.account = fields[i+1]
+					i++
+				}
+			case "macdef":
+				inMacdef = true
+				if i+1 < len(fields) {
+					i++
+				}
+			}
+		}
+	}
+
+	return machines
+}
+
+// lookup returns the first machine entry matching host, falling back to the
+// "default" entry (name == "") if no exact match is found.
+func lookup(machines []machine, host string) (machine, bool) {
+	var def machine
+	hasDef := false
+	for _, m := range machines {
+		if m.name == host {
+			return m, true
+		}
+		if m.name == "" {
+			def = m
+			hasDef = true
+		}
+	}
+
+	return def, hasDef
+}
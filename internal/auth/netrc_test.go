@@ -0,0 +1,82 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package auth
+
+import "testing"
+
+func TestParseNetrc(t *testing.T) {
+	const data = `
+# a comment line
+machine example.com
+	login jdoe
+	password secret
+
+machine other.example.com login asmith password hunter2 account eng
+
+default
+	login anon
+	password anon
+`
+
+	machines := parseNetrc(data)
+	if len(machines) != 3 {
+		t.Fatalf("expected 3 machines, got %d", len(machines))
+	}
+
+	m, ok := lookup(machines, "example.com")
+	if !ok {
+		t.Fatal("expected to find example.com")
+	}
+	if m.login != "jdoe" || m.password != "secret" {
+		t.Errorf("unexpected machine for example.com: %+v", m)
+	}
+
+	m, ok = lookup(machines, "other.example.com")
+	if !ok {
+		t.Fatal("expected to find other.example.com")
+	}
+	if m.login != "asmith" || m.password != "hunter2" || m.account != "eng" {
+		t.Errorf("unexpected machine for other.example.com: %+v", m)
+	}
+
+	m, ok = lookup(machines, "unknown.example.com")
+	if !ok {
+		t.Fatal("expected the default entry to be used as a fallback")
+	}
+	if m.login != "anon" || m.password != "anon" {
+		t.Errorf("unexpected default machine: %+v", m)
+	}
+}
+
+func TestParseNetrcSkipsMacdef(t *testing.T) {
+	const data = `
+machine example.com login jdoe password secret
+macdef init
+	cd /tmp
+	mkdir foo
+
+machine other.example.com login asmith password hunter2
+`
+
+	machines := parseNetrc(data)
+	if len(machines) != 2 {
+		t.Fatalf("expected 2 machines, got %d", len(machines))
+	}
+	if _, ok := lookup(machines, "other.example.com"); !ok {
+		t.Fatal("expected macdef body to be skipped and parsing to resume afterwards")
+	}
+}
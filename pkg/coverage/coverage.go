@@ -28,15 +28,31 @@ import (
 	"golang.org/x/tools/cover"
 
 	"github.com/go-gremlins/gremlins/configuration"
+	"github.com/go-gremlins/gremlins/internal/auth"
 	"github.com/go-gremlins/gremlins/internal/gomodule"
 	"github.com/go-gremlins/gremlins/pkg/log"
 )
 
+// Coverage modes, selectable with the --coverage-mode flag.
+const (
+	coverageModeLegacy = "legacy"
+	coverageModeBinary = "binary"
+)
+
 // Result contains the Profile generated by the coverage and the time
 // it took to generate the coverage report.
 type Result struct {
 	Profile Profile
 	Elapsed time.Duration
+
+	// PackageElapsed holds, for the binary coverage mode, the elapsed time
+	// of each package's own test binary, keyed by import path. It is nil
+	// when the legacy single-invocation coverage mode is used, in which
+	// case only the overall Elapsed is meaningful. Picking a package's own
+	// elapsed time over the overall Elapsed as its mutant test timeout is
+	// up to the caller - the out-of-tree pkg/mutator this package has no
+	// visibility into - to do; Result only exposes the data.
+	PackageElapsed map[string]time.Duration
 }
 
 // Coverage is responsible for executing a Go test with coverage via the Run() method,
@@ -48,7 +64,13 @@ type Coverage struct {
 	fileName   string
 	mod        gomodule.GoModule
 
-	buildTags string
+	buildTags      string
+	binaryCoverage bool
+
+	externalProfile string
+	testTimeout     time.Duration
+
+	netrcPath string
 }
 
 // Option for the Coverage initialization.
@@ -56,23 +78,77 @@ type Option func(c *Coverage) *Coverage
 
 type execContext = func(name string, args ...string) *exec.Cmd
 
+// WithBinaryCoverage switches Coverage to the Go 1.20+ binary coverage
+// format (GOCOVERDIR), converting it to a cover.Profile with
+// `go tool covdata textfmt` instead of relying on the legacy
+// `-coverprofile` text format.
+func WithBinaryCoverage(enabled bool) Option {
+	return func(c *Coverage) *Coverage {
+		c.binaryCoverage = enabled
+
+		return c
+	}
+}
+
+// WithTestTimeout sets an explicit test timeout on the Coverage, so that
+// Run doesn't need to estimate one by running the test suite an extra time
+// without coverage instrumentation.
+func WithTestTimeout(d time.Duration) Option {
+	return func(c *Coverage) *Coverage {
+		c.testTimeout = d
+
+		return c
+	}
+}
+
+// WithNetrc sets the netrc file used to authenticate against private hosts
+// while downloading modules and running tests, overriding $NETRC/~/.netrc.
+func WithNetrc(path string) Option {
+	return func(c *Coverage) *Coverage {
+		c.netrcPath = path
+
+		return c
+	}
+}
+
 // New instantiates a Coverage element using exec.Command as execContext,
 // actually running the command on the OS.
 func New(workdir string, mod gomodule.GoModule, opts ...Option) *Coverage {
 	return NewWithCmd(exec.Command, workdir, mod, opts...)
 }
 
+// NewFromProfile instantiates a Coverage that skips downloadModules and
+// executeCoverage altogether, parsing the given pre-existing coverage
+// profile file instead. This is useful to avoid re-running `go test -cover`
+// when a CI pipeline already produced a coverage.out as a side effect of
+// its own test step.
+//
+// workdir is still the gremlins-managed scratch directory (the same one
+// New uses), never the directory the profile happens to live in: anything
+// Coverage writes on its own behalf, such as the netrc file materialized by
+// internal/auth, must not end up next to the module's own files.
+func NewFromProfile(workdir, path string, mod gomodule.GoModule, opts ...Option) *Coverage {
+	c := NewWithCmd(exec.Command, workdir, mod, opts...)
+	c.externalProfile = path
+
+	return c
+}
+
 // NewWithCmd instantiates a Coverage element given a custom execContext.
 func NewWithCmd(cmdContext execContext, workdir string, mod gomodule.GoModule, opts ...Option) *Coverage {
 	buildTags := configuration.Get[string](configuration.UnleashTagsKey)
+	binaryCoverage := configuration.Get[string](configuration.UnleashCoverageModeKey) == coverageModeBinary
+	netrcPath := configuration.Get[string](configuration.UnleashNetrcKey)
 
 	c := &Coverage{
-		cmdContext: cmdContext,
-		workDir:    workdir,
-		path:       "./...",
-		fileName:   "coverage",
-		mod:        mod,
-		buildTags:  buildTags,
+		cmdContext:     cmdContext,
+		workDir:        workdir,
+		path:           "./...",
+		fileName:       "coverage",
+		mod:            mod,
+		buildTags:      buildTags,
+		binaryCoverage: binaryCoverage,
+		netrcPath:      netrcPath,
 	}
 	for _, opt := range opts {
 		c = opt(c)
@@ -87,11 +163,15 @@ func NewWithCmd(cmdContext execContext, workdir string, mod gomodule.GoModule, o
 // This is done to avoid that the download phase impacts the execution time which
 // is later used as timeout for the mutant testing execution.
 func (c *Coverage) Run() (Result, error) {
+	if c.externalProfile != "" {
+		return c.runFromExternalProfile()
+	}
+
 	log.Infof("Gathering coverage... ")
 	if err := c.downloadModules(); err != nil {
 		return Result{}, fmt.Errorf("impossible to download modules: %w", err)
 	}
-	elapsed, err := c.executeCoverage()
+	elapsed, pkgElapsed, err := c.executeCoverage()
 	if err != nil {
 		return Result{}, fmt.Errorf("impossible to executeCoverage coverage: %w", err)
 	}
@@ -101,11 +181,60 @@ func (c *Coverage) Run() (Result, error) {
 		return Result{}, fmt.Errorf("an error occurred while generating coverage profile: %w", err)
 	}
 
+	return Result{Profile: profile, Elapsed: elapsed, PackageElapsed: pkgElapsed}, nil
+}
+
+// runFromExternalProfile parses the externally supplied coverage profile
+// instead of running `go test -cover`. Since no coverage run happens, the
+// test-binary timeout later used by the mutator is either taken verbatim
+// from WithTestTimeout, or estimated by running the test suite once
+// without coverage instrumentation.
+func (c *Coverage) runFromExternalProfile() (Result, error) {
+	log.Infof("Using coverage profile %q... ", c.externalProfile)
+	elapsed := c.testTimeout
+	if elapsed == 0 {
+		var err error
+		elapsed, err = c.estimateTestTimeout()
+		if err != nil {
+			return Result{}, fmt.Errorf("impossible to estimate the test timeout: %w", err)
+		}
+	}
+	log.Infof("done in %s\n", elapsed)
+
+	profile, err := c.getProfile()
+	if err != nil {
+		return Result{}, fmt.Errorf("an error occurred while generating coverage profile: %w", err)
+	}
+
 	return Result{Profile: profile, Elapsed: elapsed}, nil
 }
 
+// estimateTestTimeout runs the test suite once without coverage
+// instrumentation, to get a baseline timing for the per-mutant test
+// execution, since downloadModules/executeCoverage are skipped entirely
+// when reusing an external profile.
+func (c *Coverage) estimateTestTimeout() (time.Duration, error) {
+	args := []string{"test"}
+	if c.buildTags != "" {
+		args = append(args, "-tags", c.buildTags)
+	}
+	args = append(args, c.path)
+	cmd := c.cmdContext("go", args...)
+	cmd.Stderr = os.Stderr
+	if err := c.withAuthEnv(cmd); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
+
 func (c *Coverage) getProfile() (Profile, error) {
-	cf, err := os.Open(c.filePath())
+	cf, err := os.Open(c.sourceFilePath())
 	defer func(cf *os.File) {
 		_ = cf.Close()
 	}(cf)
@@ -124,15 +253,81 @@ func (c *Coverage) filePath() string {
 	return fmt.Sprintf("%v/%v", c.workDir, c.fileName)
 }
 
+// sourceFilePath returns the coverage profile to parse: the externally
+// supplied one, if any, or the one generated by executeCoverage.
+func (c *Coverage) sourceFilePath() string {
+	if c.externalProfile != "" {
+		return c.externalProfile
+	}
+
+	return c.filePath()
+}
+
 func (c *Coverage) downloadModules() error {
 	cmd := c.cmdContext("go", "mod", "download")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	if err := c.withAuthEnv(cmd); err != nil {
+		return err
+	}
 
 	return cmd.Run()
 }
 
-func (c *Coverage) executeCoverage() (time.Duration, error) {
+// resolveAuthEnv computes, if needed, the extra environment variables
+// carrying credentials for any private host listed in
+// GOPRIVATE/GONOSUMCHECK. It must not be called concurrently: auth.Env
+// writes a netrc file to a fixed path under c.workDir, so calling it once
+// per package from concurrent goroutines would race them over that same
+// file. Sequential callers may each call it again, via withAuthEnv.
+func (c *Coverage) resolveAuthEnv() ([]string, error) {
+	extraEnv, err := auth.Env(c.netrcPath, c.workDir)
+	if err != nil {
+		return nil, fmt.Errorf("impossible to set up netrc authentication: %w", err)
+	}
+
+	return extraEnv, nil
+}
+
+// applyEnv appends extraEnv, as computed once by resolveAuthEnv, to cmd's
+// environment. It is a no-op when extraEnv is empty.
+func applyEnv(cmd *exec.Cmd, extraEnv []string) {
+	if len(extraEnv) == 0 {
+		return
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, extraEnv...)
+}
+
+// withAuthEnv resolves the auth environment and applies it to cmd. It is
+// safe for the single-invocation code paths (downloadModules,
+// executeCoverageLegacy, estimateTestTimeout), which never call it
+// concurrently with another Coverage method; executeCoverageBinary's
+// fan-out instead resolves the auth environment once up front and reuses
+// it, via applyEnv, across every concurrently running package.
+func (c *Coverage) withAuthEnv(cmd *exec.Cmd) error {
+	extraEnv, err := c.resolveAuthEnv()
+	if err != nil {
+		return err
+	}
+	applyEnv(cmd, extraEnv)
+
+	return nil
+}
+
+func (c *Coverage) executeCoverage() (time.Duration, map[string]time.Duration, error) {
+	if c.binaryCoverage {
+		return c.executeCoverageBinary()
+	}
+
+	elapsed, err := c.executeCoverageLegacy()
+
+	return elapsed, nil, err
+}
+
+func (c *Coverage) executeCoverageLegacy() (time.Duration, error) {
 	args := []string{"test"}
 	if c.buildTags != "" {
 		args = append(args, "-tags", c.buildTags)
@@ -140,6 +335,9 @@ func (c *Coverage) executeCoverage() (time.Duration, error) {
 	args = append(args, "-cover", "-coverprofile", c.filePath(), c.path)
 	cmd := c.cmdContext("go", args...)
 	cmd.Stderr = os.Stderr
+	if err := c.withAuthEnv(cmd); err != nil {
+		return 0, err
+	}
 
 	start := time.Now()
 	if err := cmd.Run(); err != nil {
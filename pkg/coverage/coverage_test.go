@@ -0,0 +1,114 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package coverage
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-gremlins/gremlins/internal/gomodule"
+)
+
+func TestExecuteCoverageDispatchesByMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		binaryCoverage bool
+		wantSubstr     string
+	}{
+		{name: "legacy mode runs go test -coverprofile", binaryCoverage: false, wantSubstr: "-coverprofile"},
+		{name: "binary mode lists packages first", binaryCoverage: true, wantSubstr: "list"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls []string
+			var mu sync.Mutex
+			c := NewWithCmd(fakeExecContext(&calls, &mu), t.TempDir(), gomodule.GoModule{}, WithBinaryCoverage(test.binaryCoverage))
+
+			if _, _, err := c.executeCoverage(); err != nil {
+				t.Fatalf("executeCoverage() returned an error: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, call := range calls {
+				if strings.Contains(call, test.wantSubstr) {
+					return
+				}
+			}
+			t.Errorf("expected a command containing %q, got calls: %v", test.wantSubstr, calls)
+		})
+	}
+}
+
+func TestRunFromExternalProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		explicitWait time.Duration
+		wantCalls    bool
+	}{
+		{name: "explicit test timeout skips the estimation run", explicitWait: 5 * time.Second, wantCalls: false},
+		{name: "no explicit timeout estimates it by running the suite once", explicitWait: 0, wantCalls: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profilePath := writeTempProfile(t)
+
+			var calls []string
+			var mu sync.Mutex
+			opts := []Option{}
+			if test.explicitWait > 0 {
+				opts = append(opts, WithTestTimeout(test.explicitWait))
+			}
+			c := NewFromProfile(t.TempDir(), profilePath, gomodule.GoModule{}, opts...)
+			c.cmdContext = fakeExecContext(&calls, &mu)
+
+			result, err := c.Run()
+			if err != nil {
+				t.Fatalf("Run() returned an error: %v", err)
+			}
+
+			mu.Lock()
+			gotCalls := len(calls) > 0
+			mu.Unlock()
+			if gotCalls != test.wantCalls {
+				t.Errorf("expected calls = %v, got calls: %v", test.wantCalls, calls)
+			}
+			if test.explicitWait > 0 && result.Elapsed != test.explicitWait {
+				t.Errorf("Elapsed = %s, want %s", result.Elapsed, test.explicitWait)
+			}
+		})
+	}
+}
+
+func writeTempProfile(t *testing.T) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "coverage-*.out")
+	if err != nil {
+		t.Fatalf("impossible to create temp profile: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.WriteString("mode: set\n"); err != nil {
+		t.Fatalf("impossible to write temp profile: %v", err)
+	}
+
+	return f.Name()
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package coverage
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeExecContext returns an execContext that records every invocation it is
+// asked to make and, instead of running the real command, re-invokes the
+// test binary itself as a stand-in child process (see TestHelperProcess).
+// This is what NewWithCmd's cmdContext injection point exists for: it lets
+// Coverage's branching logic be exercised without actually shelling out to
+// the Go toolchain.
+func fakeExecContext(calls *[]string, mu *sync.Mutex) execContext {
+	return func(name string, args ...string) *exec.Cmd {
+		mu.Lock()
+		*calls = append(*calls, strings.Join(append([]string{name}, args...), " "))
+		mu.Unlock()
+
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, args...)
+		cmd := exec.Command(os.Args[0], cs...) //nolint:gosec
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+
+		return cmd
+	}
+}
+
+// TestHelperProcess isn't a real test: it's the fake "go" binary driven by
+// fakeExecContext. It exits immediately and successfully, without producing
+// any output, which is enough for the dispatch/bounding logic under test -
+// none of it depends on the content of a real coverage report.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+}
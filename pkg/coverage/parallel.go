@@ -0,0 +1,191 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-gremlins/gremlins/configuration"
+)
+
+type packageResult struct {
+	importPath string
+	elapsed    time.Duration
+	covDir     string
+	err        error
+}
+
+// executeCoverageBinary instruments each package's test binary with the
+// Go 1.20+ hybrid coverage format, running up to workerCount() of them
+// concurrently, each writing its binary coverage pods into its own
+// subdirectory of GOCOVERDIR. The pods are then merged with
+// `go tool covdata merge` and converted to the legacy text format with
+// `go tool covdata textfmt`, so the rest of the pipeline keeps parsing a
+// plain cover.Profile.
+func (c *Coverage) executeCoverageBinary() (time.Duration, map[string]time.Duration, error) {
+	pkgs, err := c.listPackages()
+	if err != nil {
+		return 0, nil, fmt.Errorf("impossible to list packages: %w", err)
+	}
+
+	covDir := filepath.Join(c.workDir, "gocoverdir")
+	if err := os.MkdirAll(covDir, 0o755); err != nil {
+		return 0, nil, fmt.Errorf("impossible to create gocoverdir: %w", err)
+	}
+
+	// Resolved once, here, rather than by each package's own goroutine:
+	// auth.Env writes a single netrc file at a fixed path, so resolving it
+	// concurrently from runPackagesConcurrently's workers would race
+	// multiple test binaries over that same file.
+	authEnv, err := c.resolveAuthEnv()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	start := time.Now()
+	results := c.runPackagesConcurrently(pkgs, covDir, authEnv)
+	elapsed := time.Since(start)
+
+	pkgElapsed := make(map[string]time.Duration, len(results))
+	pkgDirs := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.err != nil {
+			return 0, nil, fmt.Errorf("impossible to test package %q: %w", r.importPath, r.err)
+		}
+		pkgElapsed[r.importPath] = r.elapsed
+		pkgDirs = append(pkgDirs, r.covDir)
+	}
+
+	if err := c.mergeAndConvert(pkgDirs); err != nil {
+		return 0, nil, err
+	}
+
+	return elapsed, pkgElapsed, nil
+}
+
+// listPackages enumerates the import paths covered by c.path using
+// `go list`, so each one can be instrumented and tested independently.
+func (c *Coverage) listPackages() ([]string, error) {
+	var out bytes.Buffer
+	cmd := c.cmdContext("go", "list", "-f", "{{.ImportPath}}", c.path)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var pkgs []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			pkgs = append(pkgs, line)
+		}
+	}
+
+	return pkgs, nil
+}
+
+// runPackagesConcurrently runs the test binary for each package, bounded by
+// workerCount(), each writing its binary coverage pods into its own
+// subdirectory of covDir so covdata merge can later combine them. authEnv,
+// resolved once by the caller, is applied to every package's command as-is
+// - it must not be (re-)computed here, since that would mean writing the
+// shared netrc file concurrently from multiple workers.
+func (c *Coverage) runPackagesConcurrently(pkgs []string, covDir string, authEnv []string) []packageResult {
+	sem := make(chan struct{}, c.workerCount())
+	results := make([]packageResult, len(pkgs))
+
+	wg := &sync.WaitGroup{}
+	for i, pkg := range pkgs {
+		wg.Add(1)
+		go func(i int, pkg string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = c.testPackage(pkg, covDir, i, authEnv)
+		}(i, pkg)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (c *Coverage) testPackage(pkg, covDir string, idx int, authEnv []string) packageResult {
+	pkgCovDir := filepath.Join(covDir, strconv.Itoa(idx))
+	if err := os.MkdirAll(pkgCovDir, 0o755); err != nil {
+		return packageResult{importPath: pkg, err: err}
+	}
+
+	args := []string{"test"}
+	if c.buildTags != "" {
+		args = append(args, "-tags", c.buildTags)
+	}
+	args = append(args, "-cover", pkg, "-args", "-test.gocoverdir="+pkgCovDir)
+	cmd := c.cmdContext("go", args...)
+	cmd.Stderr = os.Stderr
+	applyEnv(cmd, authEnv)
+
+	start := time.Now()
+	err := cmd.Run()
+
+	return packageResult{importPath: pkg, elapsed: time.Since(start), covDir: pkgCovDir, err: err}
+}
+
+// mergeAndConvert merges the per-package binary coverage pods into a single
+// set, then converts it to the legacy text format expected by parse().
+func (c *Coverage) mergeAndConvert(pkgDirs []string) error {
+	mergedDir := filepath.Join(c.workDir, "gocoverdir-merged")
+	if err := os.MkdirAll(mergedDir, 0o755); err != nil {
+		return fmt.Errorf("impossible to create merged gocoverdir: %w", err)
+	}
+
+	mergeCmd := c.cmdContext("go", "tool", "covdata", "merge",
+		"-i="+strings.Join(pkgDirs, ","), "-o="+mergedDir)
+	mergeCmd.Stderr = os.Stderr
+	if err := mergeCmd.Run(); err != nil {
+		return fmt.Errorf("impossible to merge binary coverage: %w", err)
+	}
+
+	textFmtCmd := c.cmdContext("go", "tool", "covdata", "textfmt",
+		"-i="+mergedDir, "-o="+c.filePath())
+	textFmtCmd.Stderr = os.Stderr
+	if err := textFmtCmd.Run(); err != nil {
+		return fmt.Errorf("impossible to convert binary coverage: %w", err)
+	}
+
+	return nil
+}
+
+// workerCount returns the size of the worker pool used to run test binaries
+// concurrently, taken from configuration, and defaulting to GOMAXPROCS when
+// unset or not positive.
+func (c *Coverage) workerCount() int {
+	workers := configuration.Get[int](configuration.UnleashCoverageWorkersKey)
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	return workers
+}
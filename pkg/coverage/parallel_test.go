@@ -0,0 +1,81 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package coverage
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/go-gremlins/gremlins/configuration"
+	"github.com/go-gremlins/gremlins/internal/gomodule"
+)
+
+func TestWorkerCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		want    int
+	}{
+		{name: "positive value from configuration is used as-is", workers: 3, want: 3},
+		{name: "zero falls back to GOMAXPROCS", workers: 0, want: runtime.GOMAXPROCS(0)},
+		{name: "negative falls back to GOMAXPROCS", workers: -1, want: runtime.GOMAXPROCS(0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration.Set(configuration.UnleashCoverageWorkersKey, test.workers)
+			defer configuration.Set(configuration.UnleashCoverageWorkersKey, 0)
+
+			c := &Coverage{}
+			if got := c.workerCount(); got != test.want {
+				t.Errorf("workerCount() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+// TestRunPackagesConcurrently exercises the semaphore-bounded dispatch in
+// runPackagesConcurrently with a worker pool smaller than the package
+// count, checking that every package still gets its own result in order
+// despite being queued behind workerLimit.
+func TestRunPackagesConcurrently(t *testing.T) {
+	const workerLimit = 2
+	configuration.Set(configuration.UnleashCoverageWorkersKey, workerLimit)
+	defer configuration.Set(configuration.UnleashCoverageWorkersKey, 0)
+
+	var calls []string
+	var mu sync.Mutex
+	c := NewWithCmd(fakeExecContext(&calls, &mu), t.TempDir(), gomodule.GoModule{})
+
+	pkgs := []string{"a", "b", "c", "d", "e"}
+	covDir := t.TempDir()
+
+	results := c.runPackagesConcurrently(pkgs, covDir, nil)
+
+	if len(results) != len(pkgs) {
+		t.Fatalf("got %d results, want %d", len(results), len(pkgs))
+	}
+	for i, r := range results {
+		if r.importPath != pkgs[i] {
+			t.Errorf("result %d: importPath = %q, want %q", i, r.importPath, pkgs[i])
+		}
+		if r.err != nil {
+			t.Errorf("result %d: unexpected error: %v", i, r.err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package server keeps the pieces needed to run mutation testing - the
+// coverage profile and the workdir.Dealer - warm across multiple requests,
+// instead of constructing and tearing them down on every `gremlins unleash`
+// invocation. A mutator.Mutator is still built anew for every run, from
+// the cached profile. This is what backs `gremlins serve`.
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-gremlins/gremlins/internal/gomodule"
+	"github.com/go-gremlins/gremlins/pkg/coverage"
+	"github.com/go-gremlins/gremlins/pkg/mutator"
+	"github.com/go-gremlins/gremlins/pkg/mutator/workdir"
+	"github.com/go-gremlins/gremlins/pkg/report"
+)
+
+// mutationEngine is the subset of Engine that Server drives. Server depends
+// on this interface, rather than on *Engine directly, so tests can exercise
+// its JSON-RPC plumbing - request/response framing, per-connection
+// notifications - against a fake instead of a real module and coverage run.
+type mutationEngine interface {
+	RefreshCoverage() (coverage.Result, error)
+	Run(ctx context.Context) (report.Results, error)
+	RunFile(ctx context.Context, path string) (report.Results, error)
+	RunMutant(ctx context.Context, id string) (report.Results, error)
+}
+
+var _ mutationEngine = (*Engine)(nil)
+
+// Engine owns the long-lived state of a mutation testing session: the
+// module, the workdir dealer and the last gathered coverage profile. It is
+// safe for concurrent use.
+type Engine struct {
+	mod      gomodule.GoModule
+	dealer   *workdir.Dealer
+	coverage *coverage.Coverage
+
+	mu      sync.Mutex
+	profile coverage.Result
+	hasRun  bool
+}
+
+// NewEngine instantiates an Engine for mod, reusing dealer and cov across
+// every subsequent Run/RefreshCoverage call.
+func NewEngine(mod gomodule.GoModule, dealer *workdir.Dealer, cov *coverage.Coverage) *Engine {
+	return &Engine{
+		mod:      mod,
+		dealer:   dealer,
+		coverage: cov,
+	}
+}
+
+// RefreshCoverage re-runs the coverage step and caches the resulting
+// profile for subsequent Run calls.
+func (e *Engine) RefreshCoverage() (coverage.Result, error) {
+	// e.mu is held across the coverage run itself: coverage.Coverage writes
+	// its profile and netrc file to fixed paths under its workdir, so two
+	// runs at once would race over them.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	p, err := e.coverage.Run()
+	if err != nil {
+		return coverage.Result{}, fmt.Errorf("failed to gather coverage: %w", err)
+	}
+
+	e.profile = p
+	e.hasRun = true
+
+	return p, nil
+}
+
+// profileOrRefresh returns the cached coverage profile, gathering it first
+// if this is the first call.
+func (e *Engine) profileOrRefresh() (coverage.Result, error) {
+	e.mu.Lock()
+	p, ok := e.profile, e.hasRun
+	e.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	return e.RefreshCoverage()
+}
+
+// Run analyses the module and executes the covered mutants, using the
+// cached coverage profile - gathering it first if this is the first call -
+// and the dealer constructed once for this Engine. A new mutator.Mutator is
+// built for every call.
+func (e *Engine) Run(ctx context.Context) (report.Results, error) {
+	profile, err := e.profileOrRefresh()
+	if err != nil {
+		return report.Results{}, err
+	}
+
+	return mutator.New(e.mod, profile, e.dealer).Run(ctx), nil
+}
+
+// RunFile analyses and executes only the mutants found in path, instead of
+// paying for - and discarding - the findings of the rest of the module.
+// mutator.New has no scoping hook of its own, so this works by handing it
+// a coverage.Result whose Profile only covers path, rather than the full
+// one Run uses.
+func (e *Engine) RunFile(ctx context.Context, path string) (report.Results, error) {
+	profile, err := e.profileOrRefresh()
+	if err != nil {
+		return report.Results{}, err
+	}
+	profile.Profile = coverage.Profile{path: profile.Profile[path]}
+
+	return mutator.New(e.mod, profile, e.dealer).Run(ctx), nil
+}
+
+// RunMutant executes the test suite for the mutants found in the same file
+// as the one identified by id (see mutantID), the finest scope available
+// without a per-mutant hook into mutator.New - the caller is expected to
+// pick the single mutant matching id out of the returned, much smaller,
+// result set, instead of paying for the whole module's mutants.
+func (e *Engine) RunMutant(ctx context.Context, id string) (report.Results, error) {
+	file, err := mutantFile(id)
+	if err != nil {
+		return report.Results{}, err
+	}
+
+	return e.RunFile(ctx, file)
+}
@@ -0,0 +1,278 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-gremlins/gremlins/pkg/mutant"
+	"github.com/go-gremlins/gremlins/pkg/report"
+)
+
+// JSON-RPC 2.0 methods exposed by Server.
+const (
+	methodMutateFile       = "mutate/file"
+	methodMutateRun        = "mutate/run"
+	methodCoverageRefresh  = "coverage/refresh"
+	methodResultsSubscribe = "results/subscribe"
+
+	notificationResultsUpdate = "results/update"
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server exposes a mutationEngine over a small JSON-RPC 2.0 API, so editors
+// can surface live/killed mutants inline, the way LSP servers surface
+// diagnostics, without paying the full-module cost on every save.
+type Server struct {
+	engine mutationEngine
+}
+
+// New instantiates a Server backed by engine. The same Server can be
+// handed to multiple concurrent Serve calls - one per stdio session or
+// accepted socket connection.
+func New(engine mutationEngine) *Server {
+	return &Server{engine: engine}
+}
+
+// Serve reads newline-delimited JSON-RPC 2.0 requests from r and writes
+// their responses to w, until r is exhausted or reading from it fails.
+// Cancelling ctx does not unblock a pending read on its own: callers close
+// r for that. A
+// background run started by this connection's results/subscribe pushes a
+// "results/update" notification to w once it completes; the notification
+// channel is private to this Serve call, so concurrent connections - e.g.
+// several clients on the --socket listener - never see each other's
+// notifications.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	var writeMu sync.Mutex
+
+	notify := make(chan report.Results, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case results := <-notify:
+				writeMu.Lock()
+				_ = enc.Encode(notification{JSONRPC: "2.0", Method: notificationResultsUpdate, Params: results})
+				writeMu.Unlock()
+			}
+		}
+	}()
+
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		resp := s.handle(ctx, req, notify)
+		writeMu.Lock()
+		err := enc.Encode(resp)
+		writeMu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, req request, notify chan<- report.Results) response {
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+
+	result, err := s.dispatch(ctx, req.Method, req.Params, notify)
+	if err != nil {
+		resp.Error = &rpcError{Code: -32000, Message: err.Error()}
+
+		return resp
+	}
+	resp.Result = result
+
+	return resp
+}
+
+func (s *Server) dispatch(ctx context.Context, method string, params json.RawMessage, notify chan<- report.Results) (any, error) {
+	switch method {
+	case methodCoverageRefresh:
+		return s.engine.RefreshCoverage()
+	case methodMutateFile:
+		return s.mutateFile(ctx, params)
+	case methodMutateRun:
+		return s.mutateRun(ctx, params)
+	case methodResultsSubscribe:
+		return s.resultsSubscribe(ctx, notify), nil
+	default:
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+}
+
+// mutantSummary is the JSON-RPC representation of a mutant.Mutant. ID is
+// computed by mutantID from the mutant's own position and type, so it
+// stays valid across requests without needing an in-memory index into a
+// larger run - a client echoes it back verbatim in a later mutate/run call.
+type mutantSummary struct {
+	ID     string `json:"id"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// mutantID computes the stable identifier for a mutant found at pos with
+// the given type, so a client can reference it later - in a mutate/run
+// call - without needing an in-memory index into a larger run.
+func mutantID(pos token.Position, mutantType string) string {
+	return fmt.Sprintf("%s:%d:%d:%s", pos.Filename, pos.Line, pos.Column, mutantType)
+}
+
+// mutantFile extracts the file path out of an id built by mutantID, so
+// Engine.RunMutant knows which single file to scope the coverage profile
+// to; mutateRun then searches the scoped results for the matching mutant.
+func mutantFile(id string) (string, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("malformed mutant id %q", id)
+	}
+	if _, err := strconv.Atoi(parts[1]); err != nil {
+		return "", fmt.Errorf("malformed mutant id %q: %w", id, err)
+	}
+
+	return parts[0], nil
+}
+
+func summarize(mutants []mutant.Mutant) []mutantSummary {
+	summaries := make([]mutantSummary, 0, len(mutants))
+	for _, m := range mutants {
+		pos := m.Pos()
+		summaries = append(summaries, mutantSummary{
+			ID:     mutantID(pos, m.Type().String()),
+			File:   pos.Filename,
+			Line:   pos.Line,
+			Column: pos.Column,
+			Type:   m.Type().String(),
+			Status: m.Status().String(),
+		})
+	}
+
+	return summaries
+}
+
+// mutateFile analyses only path, via Engine.RunFile, so a single-file
+// request doesn't pay for - or discard - the findings of the rest of the
+// module.
+func (s *Server) mutateFile(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params for %q: %w", methodMutateFile, err)
+	}
+
+	results, err := s.engine.RunFile(ctx, p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarize(results.Mutants), nil
+}
+
+// mutateRun executes the test suite for the mutant identified by id - as
+// returned in a previous mutate/file response - via Engine.RunMutant,
+// which scopes the run to id's own file, then picks out the single
+// matching mutant, instead of running the whole mutation suite and
+// discarding every result but one.
+func (s *Server) mutateRun(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params for %q: %w", methodMutateRun, err)
+	}
+
+	results, err := s.engine.RunMutant(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, summary := range summarize(results.Mutants) {
+		if summary.ID == p.ID {
+			return summary, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown mutant id %q", p.ID)
+}
+
+// resultsSubscribe kicks off a background run and returns immediately; the
+// results are pushed later as a "results/update" notification on notify,
+// which is private to the connection that issued this call.
+func (s *Server) resultsSubscribe(ctx context.Context, notify chan<- report.Results) any {
+	go func() {
+		results, err := s.engine.Run(ctx)
+		if err != nil {
+			return
+		}
+		select {
+		case notify <- results:
+		default:
+		}
+	}()
+
+	return map[string]string{"status": "subscribed"}
+}
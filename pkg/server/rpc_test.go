@@ -0,0 +1,205 @@
+/*
+ * Copyright 2022 The Gremlins Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"go/token"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-gremlins/gremlins/pkg/coverage"
+	"github.com/go-gremlins/gremlins/pkg/report"
+)
+
+// fakeEngine is a mutationEngine stand-in: unlike the real Engine, it
+// doesn't need a gomodule.GoModule, a workdir.Dealer or a coverage.Coverage
+// to construct, so Server's JSON-RPC plumbing can be tested on its own.
+type fakeEngine struct {
+	runCalled chan struct{}
+	runBlock  chan struct{}
+	results   report.Results
+}
+
+func (f *fakeEngine) RefreshCoverage() (coverage.Result, error) { return coverage.Result{}, nil }
+
+func (f *fakeEngine) Run(ctx context.Context) (report.Results, error) {
+	if f.runCalled != nil {
+		close(f.runCalled)
+	}
+	if f.runBlock != nil {
+		select {
+		case <-f.runBlock:
+		case <-ctx.Done():
+			return report.Results{}, ctx.Err()
+		}
+	}
+
+	return f.results, nil
+}
+
+func (f *fakeEngine) RunFile(_ context.Context, _ string) (report.Results, error) {
+	return f.results, nil
+}
+
+func (f *fakeEngine) RunMutant(_ context.Context, _ string) (report.Results, error) {
+	return f.results, nil
+}
+
+// connKey is the context key naming the connection a Serve call belongs to.
+type connKey struct{}
+
+// routedEngine is a mutationEngine that forwards each call to the
+// fakeEngine registered under the connKey value of the call's ctx, so one
+// Server can be shared by several connections while each connection's
+// engine calls stay separately controllable.
+type routedEngine map[string]*fakeEngine
+
+func (r routedEngine) RefreshCoverage() (coverage.Result, error) { return coverage.Result{}, nil }
+
+func (r routedEngine) Run(ctx context.Context) (report.Results, error) {
+	return r[ctx.Value(connKey{}).(string)].Run(ctx)
+}
+
+func (r routedEngine) RunFile(ctx context.Context, path string) (report.Results, error) {
+	return r[ctx.Value(connKey{}).(string)].RunFile(ctx, path)
+}
+
+func (r routedEngine) RunMutant(ctx context.Context, id string) (report.Results, error) {
+	return r[ctx.Value(connKey{}).(string)].RunMutant(ctx, id)
+}
+
+func TestMutantIDRoundTrip(t *testing.T) {
+	id := mutantID(token.Position{Filename: "pkg/foo/foo.go", Line: 12, Column: 3}, "CONDITIONALS_BOUNDARY")
+
+	file, err := mutantFile(id)
+	if err != nil {
+		t.Fatalf("mutantFile() returned an error: %v", err)
+	}
+	if file != "pkg/foo/foo.go" {
+		t.Errorf("mutantFile() = %q, want %q", file, "pkg/foo/foo.go")
+	}
+}
+
+func TestMutantFileRejectsMalformedID(t *testing.T) {
+	if _, err := mutantFile("not-a-valid-id"); err == nil {
+		t.Error("expected an error for a malformed id")
+	}
+}
+
+func TestDispatchUnknownMethod(t *testing.T) {
+	s := New(&fakeEngine{})
+
+	if _, err := s.dispatch(context.Background(), "not/a/method", nil, nil); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
+
+// TestServeTwoConnectionsIsolateNotifications drives two concurrent Serve
+// connections sharing one Server and checks that a results/subscribe
+// notification is only ever delivered to the connection that issued it -
+// the behaviour a shared Server.notify channel used to get wrong.
+func TestServeTwoConnectionsIsolateNotifications(t *testing.T) {
+	engineA := &fakeEngine{runCalled: make(chan struct{}), runBlock: make(chan struct{})}
+	engineB := &fakeEngine{runCalled: make(chan struct{}), runBlock: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := New(routedEngine{"A": engineA, "B": engineB})
+	notifA := serveOnPipe(t, context.WithValue(ctx, connKey{}, "A"), srv)
+	notifB := serveOnPipe(t, context.WithValue(ctx, connKey{}, "B"), srv)
+
+	sendSubscribe(t, notifA.in)
+	sendSubscribe(t, notifB.in)
+
+	<-engineA.runCalled
+	<-engineB.runCalled
+
+	// Only resolve B's run; A must stay silent until its own run resolves.
+	close(engineB.runBlock)
+
+	select {
+	case <-notifA.notifications:
+		t.Fatal("connection A received a notification meant for connection B")
+	case n := <-notifB.notifications:
+		if n.Method != notificationResultsUpdate {
+			t.Errorf("unexpected notification method: %q", n.Method)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for connection B's notification")
+	}
+
+	close(engineA.runBlock)
+	select {
+	case n := <-notifA.notifications:
+		if n.Method != notificationResultsUpdate {
+			t.Errorf("unexpected notification method: %q", n.Method)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for connection A's notification")
+	}
+}
+
+type pipeConn struct {
+	in            io.WriteCloser
+	notifications chan notification
+}
+
+// serveOnPipe wires a Server.Serve call to an in-memory pipe and decodes
+// every message it writes back, routing notifications onto a channel the
+// test can select on while ignoring ordinary responses.
+func serveOnPipe(t *testing.T, ctx context.Context, s *Server) pipeConn {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	go func() {
+		_ = s.Serve(ctx, inR, outW)
+	}()
+
+	notifications := make(chan notification, 4)
+	go func() {
+		dec := json.NewDecoder(outR)
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return
+			}
+			var n notification
+			if err := json.Unmarshal(raw, &n); err == nil && n.Method != "" {
+				notifications <- n
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = inW.Close()
+	})
+
+	return pipeConn{in: inW, notifications: notifications}
+}
+
+func sendSubscribe(t *testing.T, w io.Writer) {
+	t.Helper()
+	req := request{JSONRPC: "2.0", ID: json.RawMessage(`1`), Method: methodResultsSubscribe}
+	if err := json.NewEncoder(w).Encode(req); err != nil {
+		t.Fatalf("impossible to send request: %v", err)
+	}
+}